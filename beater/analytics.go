@@ -0,0 +1,187 @@
+package beater
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/elastic/beats/libbeat/common"
+
+	"github.com/google/go-github/github"
+)
+
+// collectIssueAnalytics buckets open vs. closed issues, computes
+// mean/median time-to-close, counts issues opened/closed within the
+// configured window, and splits counts by label. Only items updated since
+// the last successful run (or the configured backfill cutoff on a cold
+// start) are pulled.
+func (bt *Githubbeat) collectIssueAnalytics(owner, repository string, ctx context.Context, since time.Time) common.MapStr {
+	open, closed := 0, 0
+	byLabel := map[string]int{}
+	closeDurations := []time.Duration{}
+
+	windowStart := time.Now().Add(-bt.config.Analytics.Window)
+	openedInWindow, closedInWindow := 0, 0
+
+	_, _, err := bt.paginate("issues", func(opts *github.ListOptions) (*github.Response, error) {
+		issues, resp, err := bt.ghClient.Issues.ListByRepo(ctx, owner, repository, &github.IssueListByRepoOptions{
+			State:       "all",
+			Since:       since,
+			ListOptions: *opts,
+		})
+		if err != nil {
+			return resp, err
+		}
+
+		for _, issue := range issues {
+			if issue.IsPullRequest() {
+				continue
+			}
+
+			for _, label := range issue.Labels {
+				byLabel[label.GetName()]++
+			}
+
+			if issue.GetCreatedAt().After(windowStart) {
+				openedInWindow++
+			}
+
+			if issue.GetState() != "closed" {
+				open++
+				continue
+			}
+
+			closed++
+
+			if issue.ClosedAt != nil {
+				closeDurations = append(closeDurations, issue.ClosedAt.Sub(issue.GetCreatedAt()))
+
+				if issue.ClosedAt.After(windowStart) {
+					closedInWindow++
+				}
+			}
+		}
+
+		return resp, nil
+	})
+
+	labels := []common.MapStr{}
+	for name, count := range byLabel {
+		labels = append(labels, common.MapStr{"label": name, "count": count})
+	}
+
+	out := common.MapStr{
+		"open":                 open,
+		"closed":               closed,
+		"opened_in_window":     openedInWindow,
+		"closed_in_window":     closedInWindow,
+		"window":               bt.config.Analytics.Window.String(),
+		"mean_time_to_close":   meanDuration(closeDurations).String(),
+		"median_time_to_close": medianDuration(closeDurations).String(),
+		"by_label":             labels,
+	}
+
+	return appendError(out, err)
+}
+
+// collectPullRequestAnalytics computes time-to-first-review and
+// time-to-merge for pull requests updated since the last successful run.
+// Pull requests are listed most-recently-updated first, so pagination stops
+// as soon as a page falls outside of the since window.
+func (bt *Githubbeat) collectPullRequestAnalytics(owner, repository string, ctx context.Context, since time.Time) common.MapStr {
+	open, closed, merged := 0, 0, 0
+	firstReviewDurations := []time.Duration{}
+	mergeDurations := []time.Duration{}
+
+	_, _, err := bt.paginate("pull_requests", func(opts *github.ListOptions) (*github.Response, error) {
+		prs, resp, err := bt.ghClient.PullRequests.List(ctx, owner, repository, &github.PullRequestListOptions{
+			State:       "all",
+			Sort:        "updated",
+			Direction:   "desc",
+			ListOptions: *opts,
+		})
+		if err != nil {
+			return resp, err
+		}
+
+		for _, pr := range prs {
+			if pr.GetUpdatedAt().Before(since) {
+				return resp, errStopPaginating
+			}
+
+			switch {
+			case !pr.GetMergedAt().IsZero():
+				merged++
+				mergeDurations = append(mergeDurations, pr.GetMergedAt().Sub(pr.GetCreatedAt()))
+			case pr.GetState() == "closed":
+				closed++
+			default:
+				open++
+			}
+
+			if reviewedAt := bt.firstReviewTime(owner, repository, pr.GetNumber(), ctx); !reviewedAt.IsZero() {
+				firstReviewDurations = append(firstReviewDurations, reviewedAt.Sub(pr.GetCreatedAt()))
+			}
+		}
+
+		return resp, nil
+	})
+
+	out := common.MapStr{
+		"open":                  open,
+		"closed":                closed,
+		"merged":                merged,
+		"mean_time_to_merge":    meanDuration(mergeDurations).String(),
+		"median_time_to_merge":  medianDuration(mergeDurations).String(),
+		"mean_time_to_review":   meanDuration(firstReviewDurations).String(),
+		"median_time_to_review": medianDuration(firstReviewDurations).String(),
+	}
+
+	return appendError(out, err)
+}
+
+func (bt *Githubbeat) firstReviewTime(owner, repository string, number int, ctx context.Context) time.Time {
+	reviews, _, err := bt.ghClient.PullRequests.ListReviews(ctx, owner, repository, number, nil)
+	if err != nil || len(reviews) == 0 {
+		return time.Time{}
+	}
+
+	first := reviews[0].GetSubmittedAt()
+	for _, review := range reviews[1:] {
+		if submitted := review.GetSubmittedAt(); submitted.Before(first) {
+			first = submitted
+		}
+	}
+
+	return first
+}
+
+func meanDuration(durations []time.Duration) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+
+	var sum time.Duration
+	for _, d := range durations {
+		sum += d
+	}
+
+	return sum / time.Duration(len(durations))
+}
+
+func medianDuration(durations []time.Duration) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+
+	sorted := make([]time.Duration, len(durations))
+	copy(sorted, durations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return sorted[mid]
+	}
+
+	return (sorted[mid-1] + sorted[mid]) / 2
+}
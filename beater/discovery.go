@@ -0,0 +1,107 @@
+package beater
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+
+	"github.com/google/go-github/github"
+)
+
+// resolveQueries expands config.Queries via the GitHub search API into an
+// "owner/name" repo set, reusing the last resolved set until
+// discovery_refresh elapses so search quota isn't burned every tick. Added
+// and removed repos between refreshes are published as a
+// githubbeat.discovery event.
+func (bt *Githubbeat) resolveQueries(ctx context.Context) []string {
+	if len(bt.config.Queries) == 0 {
+		return nil
+	}
+
+	bt.discoveryMu.Lock()
+	stale := time.Now().After(bt.discoveryRefresh)
+	bt.discoveryMu.Unlock()
+
+	if !stale {
+		return bt.discoveredRepos()
+	}
+
+	resolved := map[string]bool{}
+
+	for _, query := range bt.config.Queries {
+		_, _, err := bt.paginate("discovery", func(opts *github.ListOptions) (*github.Response, error) {
+			result, resp, err := bt.ghClient.Search.Repositories(ctx, query, &github.SearchOptions{ListOptions: *opts})
+			if err != nil {
+				return resp, err
+			}
+
+			for _, repo := range result.Repositories {
+				resolved[repo.Owner.GetLogin()+"/"+repo.GetName()] = true
+			}
+
+			return resp, nil
+		})
+
+		if err != nil {
+			logp.Err("Failed to resolve discovery query %q, got: %v", query, err)
+		}
+	}
+
+	bt.publishDiscoveryDiff(resolved)
+
+	bt.discoveryMu.Lock()
+	bt.discoveryRepos = resolved
+	bt.discoveryRefresh = time.Now().Add(bt.config.DiscoveryRefresh)
+	bt.discoveryMu.Unlock()
+
+	return bt.discoveredRepos()
+}
+
+func (bt *Githubbeat) discoveredRepos() []string {
+	bt.discoveryMu.Lock()
+	defer bt.discoveryMu.Unlock()
+
+	repos := make([]string, 0, len(bt.discoveryRepos))
+	for repo := range bt.discoveryRepos {
+		repos = append(repos, repo)
+	}
+
+	return repos
+}
+
+func (bt *Githubbeat) publishDiscoveryDiff(resolved map[string]bool) {
+	bt.discoveryMu.Lock()
+	previous := bt.discoveryRepos
+	bt.discoveryMu.Unlock()
+
+	added := []string{}
+	removed := []string{}
+
+	for repo := range resolved {
+		if !previous[repo] {
+			added = append(added, repo)
+		}
+	}
+
+	for repo := range previous {
+		if !resolved[repo] {
+			removed = append(removed, repo)
+		}
+	}
+
+	if len(added) == 0 && len(removed) == 0 && previous != nil {
+		return
+	}
+
+	bt.client.PublishEvent(common.MapStr{
+		"@timestamp": common.Time(time.Now()),
+		"type":       "githubbeat.discovery",
+		"queries":    strings.Join(bt.config.Queries, "; "),
+		"added":      added,
+		"removed":    removed,
+		"total":      len(resolved),
+	})
+}
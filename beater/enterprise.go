@@ -0,0 +1,88 @@
+package beater
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/google/go-github/github"
+)
+
+// baseTransport builds the http.RoundTripper used under the caching/rate
+// limit transport, honoring insecure_skip_verify/ca_cert for GitHub
+// Enterprise Server instances running with a self-signed or privately
+// issued certificate.
+func (bt *Githubbeat) baseTransport() (http.RoundTripper, error) {
+	if !bt.config.InsecureSkipVerify && bt.config.CACert == "" {
+		return http.DefaultTransport, nil
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: bt.config.InsecureSkipVerify}
+
+	if bt.config.CACert != "" {
+		pem, err := ioutil.ReadFile(bt.config.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ca_cert: %v", err)
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in ca_cert %s", bt.config.CACert)
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	return transport, nil
+}
+
+// detectVersion extracts the GHES version from the X-GitHub-Enterprise-Version
+// header of the startup Zen ping. Some GHES versions don't set that header
+// on /zen, so when talking to an enterprise_base_url we fall back to an
+// APIMeta call and check its response header instead.
+func (bt *Githubbeat) detectVersion(ctx context.Context, client *github.Client, zenResp *github.Response) string {
+	if version := zenResp.Header.Get("X-GitHub-Enterprise-Version"); version != "" {
+		return version
+	}
+
+	if bt.config.EnterpriseBaseURL == "" {
+		return ""
+	}
+
+	_, metaResp, err := client.APIMeta(ctx)
+	if err != nil {
+		logp.Err("Failed to query APIMeta for GHES version detection, got: %v", err)
+		return ""
+	}
+
+	return metaResp.Header.Get("X-GitHub-Enterprise-Version")
+}
+
+// publishInstanceEvent emits a githubbeat.instance event once at startup,
+// capturing whether we're talking to github.com or a GitHub Enterprise
+// Server instance (and which version, when it identifies itself).
+func (bt *Githubbeat) publishInstanceEvent(version string) {
+	event := common.MapStr{
+		"@timestamp": common.Time(time.Now()),
+		"type":       "githubbeat.instance",
+		"enterprise": bt.config.EnterpriseBaseURL != "",
+	}
+
+	if version != "" {
+		event["version"] = version
+	}
+
+	bt.client.PublishEvent(event)
+}
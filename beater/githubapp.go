@@ -0,0 +1,145 @@
+package beater
+
+import (
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+
+	// dgrijalva/jwt-go is archived upstream; it's only used here for RS256
+	// signing of the short-lived app JWT. Don't add new usages elsewhere —
+	// migrate this one to its maintained fork (golang-jwt/jwt) if it ever
+	// needs to change.
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+const (
+	// appJWTExpiry is the lifetime of the JWT signed to authenticate as the
+	// GitHub App itself, capped by GitHub at 10 minutes.
+	appJWTExpiry = 10 * time.Minute
+	// installationTokenSkew is how long before its reported expiry an
+	// installation token is refreshed.
+	installationTokenSkew = 5 * time.Minute
+)
+
+// appTokenSource is an oauth2.TokenSource that authenticates as a GitHub App
+// installation instead of a static personal access token: it signs a
+// short-lived JWT with the app's private key, exchanges it for an
+// installation access token, and transparently re-exchanges a fresh JWT a
+// few minutes before the installation token expires.
+type appTokenSource struct {
+	appID          int64
+	installationID int64
+	privateKey     *rsa.PrivateKey
+	baseURL        string
+	httpClient     *http.Client
+
+	mu    sync.Mutex
+	token *oauth2.Token
+}
+
+func newAppTokenSource(appID, installationID int64, privateKeyPath, baseURL string) (oauth2.TokenSource, error) {
+	pemBytes, err := ioutil.ReadFile(privateKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private_key_path: %v", err)
+	}
+
+	key, err := jwt.ParseRSAPrivateKeyFromPEM(pemBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private_key_path: %v", err)
+	}
+
+	if baseURL == "" {
+		baseURL = "https://api.github.com"
+	}
+
+	return &appTokenSource{
+		appID:          appID,
+		installationID: installationID,
+		privateKey:     key,
+		baseURL:        strings.TrimSuffix(baseURL, "/"),
+		httpClient:     &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// Token implements oauth2.TokenSource. It returns the cached installation
+// token, or signs a fresh app JWT and exchanges it for a new installation
+// token when the cached one is missing or close to expiring.
+func (s *appTokenSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != nil && time.Now().Before(s.token.Expiry.Add(-installationTokenSkew)) {
+		return s.token, nil
+	}
+
+	appJWT, err := s.signAppJWT()
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := s.exchangeInstallationToken(appJWT)
+	if err != nil {
+		return nil, err
+	}
+
+	s.token = token
+
+	return token, nil
+}
+
+func (s *appTokenSource) signAppJWT() (string, error) {
+	now := time.Now()
+
+	claims := jwt.StandardClaims{
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(appJWTExpiry).Unix(),
+		Issuer:    strconv.FormatInt(s.appID, 10),
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(s.privateKey)
+}
+
+type installationTokenResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// exchangeInstallationToken calls POST /app/installations/{id}/access_tokens
+// authenticated with the app JWT, per the GitHub App installation auth flow.
+func (s *appTokenSource) exchangeInstallationToken(appJWT string) (*oauth2.Token, error) {
+	url := fmt.Sprintf("%s/app/installations/%d/access_tokens", s.baseURL, s.installationID)
+
+	req, err := http.NewRequest(http.MethodPost, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+appJWT)
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to create installation token, status %d: %s", resp.StatusCode, body)
+	}
+
+	var parsed installationTokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return nil, err
+	}
+
+	return &oauth2.Token{AccessToken: parsed.Token, Expiry: parsed.ExpiresAt}, nil
+}
@@ -0,0 +1,187 @@
+package beater
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+func mustGenerateRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %v", err)
+	}
+
+	return key
+}
+
+func TestSignAppJWTClaims(t *testing.T) {
+	key := mustGenerateRSAKey(t)
+	source := &appTokenSource{appID: 123, privateKey: key}
+
+	tokenString, err := source.signAppJWT()
+	if err != nil {
+		t.Fatalf("signAppJWT() returned error: %v", err)
+	}
+
+	parsed, err := jwt.ParseWithClaims(tokenString, &jwt.StandardClaims{}, func(token *jwt.Token) (interface{}, error) {
+		return &key.PublicKey, nil
+	})
+	if err != nil {
+		t.Fatalf("failed to parse signed JWT: %v", err)
+	}
+
+	claims, ok := parsed.Claims.(*jwt.StandardClaims)
+	if !ok {
+		t.Fatalf("unexpected claims type %T", parsed.Claims)
+	}
+
+	if claims.Issuer != "123" {
+		t.Errorf("expected issuer %q, got %q", "123", claims.Issuer)
+	}
+
+	lifetime := time.Unix(claims.ExpiresAt, 0).Sub(time.Unix(claims.IssuedAt, 0))
+	if lifetime != appJWTExpiry {
+		t.Errorf("expected a %s lifetime, got %s", appJWTExpiry, lifetime)
+	}
+}
+
+func TestExchangeInstallationToken(t *testing.T) {
+	wantExpiry := time.Now().Add(1 * time.Hour).UTC().Truncate(time.Second)
+
+	var gotAuth string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+
+		wantPath := "/app/installations/99/access_tokens"
+		if r.URL.Path != wantPath {
+			t.Errorf("expected request to %s, got %s", wantPath, r.URL.Path)
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"token":      "v1.installation-token",
+			"expires_at": wantExpiry.Format(time.RFC3339),
+		})
+	}))
+	defer server.Close()
+
+	source := &appTokenSource{
+		installationID: 99,
+		baseURL:        server.URL,
+		httpClient:     server.Client(),
+	}
+
+	token, err := source.exchangeInstallationToken("fake-jwt")
+	if err != nil {
+		t.Fatalf("exchangeInstallationToken() returned error: %v", err)
+	}
+
+	if gotAuth != "Bearer fake-jwt" {
+		t.Errorf("expected Authorization header %q, got %q", "Bearer fake-jwt", gotAuth)
+	}
+	if token.AccessToken != "v1.installation-token" {
+		t.Errorf("expected access token %q, got %q", "v1.installation-token", token.AccessToken)
+	}
+	if !token.Expiry.Equal(wantExpiry) {
+		t.Errorf("expected expiry %s, got %s", wantExpiry, token.Expiry)
+	}
+}
+
+func TestExchangeInstallationTokenRejectsNonCreatedStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, "bad credentials")
+	}))
+	defer server.Close()
+
+	source := &appTokenSource{baseURL: server.URL, httpClient: server.Client()}
+
+	if _, err := source.exchangeInstallationToken("fake-jwt"); err == nil {
+		t.Fatal("expected an error for a non-201 response, got nil")
+	}
+}
+
+func TestAppTokenSourceTokenReusesCachedToken(t *testing.T) {
+	calls := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"token":      "v1.installation-token",
+			"expires_at": time.Now().Add(1 * time.Hour).Format(time.RFC3339),
+		})
+	}))
+	defer server.Close()
+
+	source := &appTokenSource{
+		appID:          1,
+		installationID: 2,
+		privateKey:     mustGenerateRSAKey(t),
+		baseURL:        server.URL,
+		httpClient:     server.Client(),
+	}
+
+	if _, err := source.Token(); err != nil {
+		t.Fatalf("first Token() call returned error: %v", err)
+	}
+	if _, err := source.Token(); err != nil {
+		t.Fatalf("second Token() call returned error: %v", err)
+	}
+
+	if calls != 1 {
+		t.Errorf("expected the installation token endpoint to be hit once, got %d calls", calls)
+	}
+}
+
+func TestAppTokenSourceTokenRefreshesNearExpiry(t *testing.T) {
+	calls := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"token":      "v1.installation-token",
+			"expires_at": time.Now().Add(1 * time.Hour).Format(time.RFC3339),
+		})
+	}))
+	defer server.Close()
+
+	source := &appTokenSource{
+		appID:          1,
+		installationID: 2,
+		privateKey:     mustGenerateRSAKey(t),
+		baseURL:        server.URL,
+		httpClient:     server.Client(),
+		// Already inside the refresh skew window, so the next Token()
+		// call must fetch a new one instead of reusing this.
+		token: &oauth2.Token{AccessToken: "stale", Expiry: time.Now().Add(1 * time.Minute)},
+	}
+
+	token, err := source.Token()
+	if err != nil {
+		t.Fatalf("Token() returned error: %v", err)
+	}
+
+	if token.AccessToken != "v1.installation-token" {
+		t.Errorf("expected a refreshed token, got %q", token.AccessToken)
+	}
+	if calls != 1 {
+		t.Errorf("expected exactly one refresh call, got %d", calls)
+	}
+}
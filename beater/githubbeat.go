@@ -2,8 +2,12 @@ package beater
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"golang.org/x/oauth2"
@@ -24,6 +28,19 @@ type Githubbeat struct {
 	config   config.Config
 	ghClient *github.Client
 	client   publisher.Client
+
+	rateLimitMu            sync.Mutex
+	pausedUntil            time.Time
+	tick                   uint64
+	lastRateLimitTick      uint64
+	lastRateLimitRemaining int
+
+	analyticsRegistry *registry
+	discoveryMu       sync.Mutex
+	discoveryRepos    map[string]bool
+	discoveryRefresh  time.Time
+
+	webhookServer *http.Server
 }
 
 // New creates  a new instance of a GithubBeat
@@ -44,8 +61,9 @@ func (bt *Githubbeat) Run(b *beat.Beat) error {
 	logp.Info("githubbeat is running! Hit CTRL-C to stop it.")
 
 	bt.client = b.Publisher.Connect()
+	bt.analyticsRegistry = newRegistry(bt.config.Analytics.RegistryPath)
 
-	ghClient, err := newGithubClient(bt.config.AccessToken)
+	ghClient, err := bt.newGithubClient(bt.config.AccessToken)
 
 	if err != nil {
 		return err
@@ -53,6 +71,12 @@ func (bt *Githubbeat) Run(b *beat.Beat) error {
 
 	bt.ghClient = ghClient
 
+	if bt.config.Mode == config.ModeWebhook || bt.config.Mode == config.ModeBoth {
+		if err := bt.startWebhookServer(); err != nil {
+			return err
+		}
+	}
+
 	ticker := time.NewTicker(bt.config.Period)
 
 	rootCtx, cancelRootCtx := context.WithCancel(context.Background())
@@ -63,10 +87,25 @@ func (bt *Githubbeat) Run(b *beat.Beat) error {
 			cancelRootCtx()
 			return nil
 		case <-ticker.C:
+			if bt.config.Mode == config.ModeWebhook {
+				continue
+			}
+
+			if resumeAt := bt.resumeAt(); time.Now().Before(resumeAt) {
+				logp.Info("Rate limit exhausted, skipping this tick until %s.", resumeAt)
+				continue
+			}
+
+			bt.nextTick()
+
 			logp.Info("Collecting events.")
 			jobCtx, jobCancel := context.WithTimeout(rootCtx, bt.config.JobTimeout)
 			defer jobCancel()
-			bt.collectReposEvents(jobCtx, bt.config.Repos)
+
+			repos := append([]string{}, bt.config.Repos...)
+			repos = append(repos, bt.resolveQueries(jobCtx)...)
+
+			bt.collectReposEvents(jobCtx, repos)
 			bt.collectOrgsEvents(jobCtx, bt.config.Orgs)
 		}
 	}
@@ -74,49 +113,239 @@ func (bt *Githubbeat) Run(b *beat.Beat) error {
 
 // Stop stops the running beat
 func (bt *Githubbeat) Stop() {
+	bt.stopWebhookServer()
 	bt.client.Close()
 	close(bt.done)
 }
 
-func newGithubClient(accessToken string) (*github.Client, error) {
-	if accessToken == "" {
-		logp.Info("Running in unauthentcated mode.")
-		return github.NewClient(nil), nil
+func (bt *Githubbeat) newGithubClient(accessToken string) (*github.Client, error) {
+	ctx := context.Background()
+
+	appFieldsSet := boolCount(bt.config.AppID != 0, bt.config.InstallationID != 0, bt.config.PrivateKeyPath != "")
+	usingApp := appFieldsSet == 3
+
+	if appFieldsSet > 0 && appFieldsSet < 3 {
+		return nil, fmt.Errorf("app_id, installation_id and private_key_path must all be set together")
+	}
+
+	if usingApp && accessToken != "" {
+		return nil, fmt.Errorf("access_token and app_id/installation_id/private_key_path are mutually exclusive")
 	}
 
-	logp.Info("Running in authentcated mode.")
+	baseTransport, err := bt.baseTransport()
+	if err != nil {
+		return nil, err
+	}
 
-	ctx := context.Background()
-	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: accessToken},
-	)
+	// oauth2.NewClient reads the base *http.Client it wraps from the
+	// context, so the TLS-aware base transport has to be injected before
+	// the oauth2 client is built. The oauth2 Transport it returns (which
+	// attaches the Authorization header) is then wrapped by the caching
+	// transport, not the other way around, or every request would go out
+	// unauthenticated.
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Transport: baseTransport})
+
+	var httpClient *http.Client
+
+	switch {
+	case usingApp:
+		logp.Info("Running in GitHub App installation mode.")
+
+		ts, err := newAppTokenSource(bt.config.AppID, bt.config.InstallationID, bt.config.PrivateKeyPath, bt.config.EnterpriseBaseURL)
+		if err != nil {
+			return nil, err
+		}
 
-	client := github.NewClient(oauth2.NewClient(ctx, ts))
+		httpClient = oauth2.NewClient(ctx, ts)
+	case accessToken != "":
+		logp.Info("Running in authentcated mode.")
 
-	if _, _, err := client.Repositories.List(ctx, "", nil); err != nil {
+		ts := oauth2.StaticTokenSource(
+			&oauth2.Token{AccessToken: accessToken},
+		)
+
+		httpClient = oauth2.NewClient(ctx, ts)
+	default:
+		logp.Info("Running in unauthentcated mode.")
+		httpClient = &http.Client{Transport: baseTransport}
+	}
+
+	httpClient.Transport = newCachingTransport(httpClient.Transport, bt)
+
+	client, err := bt.newClientForBaseURL(httpClient)
+	if err != nil {
 		return nil, err
 	}
 
+	_, resp, err := client.Zen(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	bt.publishInstanceEvent(bt.detectVersion(ctx, client, resp))
+
 	return client, nil
 }
 
+// newClientForBaseURL builds a github.Client pointed at api.github.com, or
+// at the configured GitHub Enterprise Server instance when
+// enterprise_base_url/enterprise_upload_url are set.
+func (bt *Githubbeat) newClientForBaseURL(httpClient *http.Client) (*github.Client, error) {
+	if bt.config.EnterpriseBaseURL == "" {
+		return github.NewClient(httpClient), nil
+	}
+
+	return github.NewEnterpriseClient(bt.config.EnterpriseBaseURL, bt.config.EnterpriseUploadURL, httpClient)
+}
+
+// resumeAt returns the time at which polling should resume after a rate
+// limit pause. It returns the zero time when no pause is in effect.
+func (bt *Githubbeat) resumeAt() time.Time {
+	bt.rateLimitMu.Lock()
+	defer bt.rateLimitMu.Unlock()
+
+	return bt.pausedUntil
+}
+
+// nextTick marks the start of a new collection cycle, so checkRateLimit can
+// tell which responses belong to the same tick and only publish one
+// githubbeat.ratelimit event per cycle.
+func (bt *Githubbeat) nextTick() {
+	bt.rateLimitMu.Lock()
+	bt.tick++
+	bt.rateLimitMu.Unlock()
+}
+
+// checkRateLimit inspects the X-RateLimit-* headers of a GitHub API
+// response and pauses the polling ticker until the rate limit window resets
+// once the remaining quota drops below the configured threshold. A
+// githubbeat.ratelimit event is published for the first response of each
+// tick and, within a tick, only when remaining differs from the last
+// published value, so responses that leave the quota unchanged (such as
+// 304 replays) do not each produce an event.
+func (bt *Githubbeat) checkRateLimit(resp *http.Response) {
+	remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+
+	resetUnix, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return
+	}
+
+	reset := time.Unix(resetUnix, 0)
+	belowThreshold := remaining < bt.config.RateLimitThreshold
+
+	bt.rateLimitMu.Lock()
+	shouldPublish := bt.tick != bt.lastRateLimitTick || remaining != bt.lastRateLimitRemaining
+	if shouldPublish {
+		bt.lastRateLimitTick = bt.tick
+		bt.lastRateLimitRemaining = remaining
+	}
+	if belowThreshold {
+		bt.pausedUntil = reset
+	}
+	bt.rateLimitMu.Unlock()
+
+	if shouldPublish && bt.client != nil {
+		bt.client.PublishEvent(common.MapStr{
+			"@timestamp": common.Time(time.Now()),
+			"type":       "githubbeat.ratelimit",
+			"remaining":  remaining,
+			"reset":      common.Time(reset),
+		})
+	}
+
+	if belowThreshold {
+		logp.Info("Rate limit remaining (%d) below threshold (%d), pausing until %s.", remaining, bt.config.RateLimitThreshold, reset)
+	}
+}
+
 func (bt *Githubbeat) collectOrgsEvents(ctx context.Context, orgs []string) {
 	for _, org := range orgs {
 		go func(ctx context.Context, org string) {
-			repos, _, err := bt.ghClient.Repositories.ListByOrg(ctx, org, nil)
+			allRepos := []*github.Repository{}
+
+			_, _, err := bt.paginate("org_repos", func(opts *github.ListOptions) (*github.Response, error) {
+				repos, resp, err := bt.ghClient.Repositories.ListByOrg(ctx, org, &github.RepositoryListByOrgOptions{ListOptions: *opts})
+				if err != nil {
+					return resp, err
+				}
+
+				allRepos = append(allRepos, repos...)
+				return resp, nil
+			})
 
 			if err != nil {
 				logp.Err("Failed to collect org repos listing, got :", err)
 				return
 			}
 
-			for _, repo := range repos {
+			for _, repo := range allRepos {
 				bt.client.PublishEvent(bt.newFullRepoEvent(ctx, repo))
 			}
 		}(ctx, org)
 	}
 }
 
+// paginate repeatedly calls fetch, threading GitHub's pagination cursor
+// through a *github.ListOptions until GitHub reports no further page, the
+// collector's configured max-page cap is reached, or fetch returns an error.
+// It returns the number of pages retrieved and whether the result was
+// truncated by the cap.
+func (bt *Githubbeat) paginate(collector string, fetch func(opts *github.ListOptions) (*github.Response, error)) (pages int, truncated bool, err error) {
+	perPage, maxPages := bt.pageLimits(collector)
+	opts := &github.ListOptions{PerPage: perPage}
+
+	for {
+		resp, err := fetch(opts)
+
+		if err == errStopPaginating {
+			pages++
+			return pages, false, nil
+		}
+
+		if err != nil {
+			return pages, truncated, err
+		}
+
+		pages++
+
+		if resp.NextPage == 0 {
+			return pages, false, nil
+		}
+
+		if maxPages > 0 && pages >= maxPages {
+			return pages, true, nil
+		}
+
+		opts.Page = resp.NextPage
+	}
+}
+
+// errStopPaginating is a sentinel a fetch callback can return to end
+// pagination early without it being reported as a collector error, e.g.
+// once a page sorted by update time falls outside of an analytics window.
+var errStopPaginating = errors.New("stop paginating")
+
+// pageLimits resolves the effective per_page/max_pages for a collector,
+// falling back to the beat-wide defaults when no override is configured.
+func (bt *Githubbeat) pageLimits(collector string) (perPage, maxPages int) {
+	perPage, maxPages = bt.config.PerPage, bt.config.MaxPages
+
+	if override, ok := bt.config.PageLimits[collector]; ok {
+		if override.PerPage > 0 {
+			perPage = override.PerPage
+		}
+		if override.MaxPages > 0 {
+			maxPages = override.MaxPages
+		}
+	}
+
+	return perPage, maxPages
+}
+
 func (bt *Githubbeat) collectReposEvents(ctx context.Context, repos []string) {
 	for _, repoName := range repos {
 		go func(ctx context.Context, repo string) {
@@ -142,41 +371,59 @@ func (bt *Githubbeat) collectReposEvents(ctx context.Context, repos []string) {
 func (bt *Githubbeat) getContributions(owner, repository string, ctx context.Context) common.MapStr {
 	users := []common.MapStr{}
 	total := 0
-	
-	contributors, _, err := bt.ghClient.Repositories.ListContributors(ctx, owner, repository, nil)
-	if err == nil {
+
+	pages, truncated, err := bt.paginate("contributors", func(opts *github.ListOptions) (*github.Response, error) {
+		contributors, resp, err := bt.ghClient.Repositories.ListContributors(ctx, owner, repository, &github.ListContributorsOptions{ListOptions: *opts})
+		if err != nil {
+			return resp, err
+		}
+
 		for _, contributor := range contributors {
 			userInfo := common.MapStr {
 				"name": contributor.GetLogin(),
 				"contributions": contributor.GetContributions(),
 			}
-			
-			users = append(users, userInfo) 
-			
+
+			users = append(users, userInfo)
+
 			total += contributor.GetContributions()
 		}
-	}
-	
-	return createListMapStr(users, err)
+
+		return resp, nil
+	})
+
+	out := createListMapStr(users, err)
+	out["total_pages"] = pages
+	out["truncated"] = truncated
+	return out
 }
 
 func (bt *Githubbeat) getBranches(owner, repository string, ctx context.Context) common.MapStr {
 	// name:author pairs
 	branchList := []common.MapStr{}
-	
-	branches, _, err := bt.ghClient.Repositories.ListBranches(ctx, owner, repository, nil)
-	if err == nil {
+
+	pages, truncated, err := bt.paginate("branches", func(opts *github.ListOptions) (*github.Response, error) {
+		branches, resp, err := bt.ghClient.Repositories.ListBranches(ctx, owner, repository, opts)
+		if err != nil {
+			return resp, err
+		}
+
 		for _, branch := range branches {
 			branchInfo := common.MapStr {
 				"name": branch.GetName(),
-				"sha": branch.Commit.GetSHA(), 
+				"sha": branch.Commit.GetSHA(),
 			}
-			
+
 			branchList = append(branchList, branchInfo)
 		}
-	}
-	
-	return createListMapStr(branchList, err)
+
+		return resp, nil
+	})
+
+	out := createListMapStr(branchList, err)
+	out["total_pages"] = pages
+	out["truncated"] = truncated
+	return out
 }
 
 func (bt *Githubbeat) newFullRepoEvent(ctx context.Context, repo *github.Repository) common.MapStr {
@@ -199,7 +446,22 @@ func (bt *Githubbeat) newFullRepoEvent(ctx context.Context, repo *github.Reposit
 	data["languages"] = bt.collectLanguageInfo(owner, repository, ctx)
 	data["participation"] = bt.collectParticipation(owner, repository, ctx)
 	data["downloads"] = bt.collectDownloads(owner, repository, ctx)
-	
+	data["statistics"] = bt.collectStatistics(owner, repository, ctx)
+
+	registryKey := owner + "/" + repository
+	since := bt.analyticsRegistry.since(registryKey, bt.config.Analytics.Backfill)
+	syncStart := time.Now()
+
+	issues := bt.collectIssueAnalytics(owner, repository, ctx, since)
+	pullRequests := bt.collectPullRequestAnalytics(owner, repository, ctx, since)
+
+	data["issues"] = issues
+	data["pull_requests"] = pullRequests
+
+	if issues["error"] == nil && pullRequests["error"] == nil {
+		bt.analyticsRegistry.markSynced(registryKey, syncStart)
+	}
+
 	return data
 }
 
@@ -239,14 +501,25 @@ func (bt *Githubbeat) collectLanguageInfo(owner, repository string, ctx context.
 }
 
 func (bt *Githubbeat) collectForkInfo(owner, repository string, ctx context.Context) common.MapStr {
-	forks, _, err := bt.ghClient.Repositories.ListForks(ctx, owner, repository, nil)
-	
 	forkInfo := []common.MapStr{}
-	for _, repo := range forks {
-		forkInfo = append(forkInfo, bt.extractRepoData(repo))
-	}
-	
-	return createListMapStr(forkInfo, err)
+
+	pages, truncated, err := bt.paginate("forks", func(opts *github.ListOptions) (*github.Response, error) {
+		forks, resp, err := bt.ghClient.Repositories.ListForks(ctx, owner, repository, &github.RepositoryListForksOptions{ListOptions: *opts})
+		if err != nil {
+			return resp, err
+		}
+
+		for _, repo := range forks {
+			forkInfo = append(forkInfo, bt.extractRepoData(repo))
+		}
+
+		return resp, nil
+	})
+
+	out := createListMapStr(forkInfo, err)
+	out["total_pages"] = pages
+	out["truncated"] = truncated
+	return out
 }
 
 func (bt *Githubbeat) collectLicenseInfo(owner, repository string, ctx context.Context) common.MapStr {
@@ -294,29 +567,39 @@ func (bt *Githubbeat) extractParticipationData(participation *github.RepositoryP
 }
 
 func (bt *Githubbeat) collectDownloads(owner, repository string, ctx context.Context) common.MapStr {
-	releases, _, err := bt.ghClient.Repositories.ListReleases(ctx, owner, repository, nil)
-	
 	totalDownloads := 0
 	out := []common.MapStr{}
-	for _, release := range releases {
-		releaseDownloads := 0
-		
-		for _, asset := range release.Assets {
-			releaseDownloads += asset.GetDownloadCount()
+
+	pages, truncated, err := bt.paginate("downloads", func(opts *github.ListOptions) (*github.Response, error) {
+		releases, resp, err := bt.ghClient.Repositories.ListReleases(ctx, owner, repository, opts)
+		if err != nil {
+			return resp, err
 		}
-		
-		totalDownloads += releaseDownloads
-		
-		out = append(out, common.MapStr {
-			"id": release.GetID(),
-			"name": release.GetName(),
-			"downloads": releaseDownloads,
-		})
-	}
+
+		for _, release := range releases {
+			releaseDownloads := 0
+
+			for _, asset := range release.Assets {
+				releaseDownloads += asset.GetDownloadCount()
+			}
+
+			totalDownloads += releaseDownloads
+
+			out = append(out, common.MapStr {
+				"id": release.GetID(),
+				"name": release.GetName(),
+				"downloads": releaseDownloads,
+			})
+		}
+
+		return resp, nil
+	})
 
 	return common.MapStr {
 		"total_downloads": totalDownloads,
 		"releases": out,
+		"total_pages": pages,
+		"truncated": truncated,
 		"error": err,
 	}
 }
@@ -337,6 +620,17 @@ func appendError(input common.MapStr, err error) common.MapStr {
 	return input
 }
 
+func boolCount(values ...bool) int {
+	count := 0
+	for _, v := range values {
+		if v {
+			count++
+		}
+	}
+
+	return count
+}
+
 func sumIntArray(array []int) int {
 	sum := 0
 	for _, i := range array {
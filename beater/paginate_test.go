@@ -0,0 +1,133 @@
+package beater
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-github/github"
+
+	"github.com/jlevesy/githubbeat/config"
+)
+
+func TestPaginateStopsAtLastPage(t *testing.T) {
+	bt := &Githubbeat{}
+
+	calls := 0
+	pages, truncated, err := bt.paginate("test", func(opts *github.ListOptions) (*github.Response, error) {
+		calls++
+
+		if opts.Page == 0 {
+			return &github.Response{NextPage: 2}, nil
+		}
+
+		return &github.Response{NextPage: 0}, nil
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pages != 2 {
+		t.Errorf("expected 2 pages fetched, got %d", pages)
+	}
+	if truncated {
+		t.Errorf("expected truncated to be false")
+	}
+	if calls != 2 {
+		t.Errorf("expected fetch to be called twice, got %d", calls)
+	}
+}
+
+func TestPaginateTruncatesAtMaxPages(t *testing.T) {
+	bt := &Githubbeat{}
+	bt.config.MaxPages = 2
+
+	calls := 0
+	_, truncated, err := bt.paginate("test", func(opts *github.ListOptions) (*github.Response, error) {
+		calls++
+		// Always report another page available, so only the max_pages cap
+		// can stop the loop.
+		return &github.Response{NextPage: opts.Page + 1}, nil
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !truncated {
+		t.Errorf("expected truncated to be true")
+	}
+	if calls != 2 {
+		t.Errorf("expected fetch to stop after 2 pages, got %d calls", calls)
+	}
+}
+
+func TestPaginateStopsEarlyOnSentinel(t *testing.T) {
+	bt := &Githubbeat{}
+
+	calls := 0
+	pages, truncated, err := bt.paginate("test", func(opts *github.ListOptions) (*github.Response, error) {
+		calls++
+
+		if calls == 1 {
+			return &github.Response{NextPage: 2}, nil
+		}
+
+		return &github.Response{NextPage: 3}, errStopPaginating
+	})
+
+	if err != nil {
+		t.Fatalf("expected errStopPaginating not to be surfaced, got: %v", err)
+	}
+	if truncated {
+		t.Errorf("expected truncated to be false on an early stop")
+	}
+	if pages != 2 {
+		t.Errorf("expected 2 pages fetched before stopping, got %d", pages)
+	}
+	if calls != 2 {
+		t.Errorf("expected fetch to be called twice, got %d", calls)
+	}
+}
+
+func TestPaginatePropagatesFetchError(t *testing.T) {
+	bt := &Githubbeat{}
+	wantErr := errors.New("boom")
+
+	_, _, err := bt.paginate("test", func(opts *github.ListOptions) (*github.Response, error) {
+		return nil, wantErr
+	})
+
+	if err != wantErr {
+		t.Errorf("expected error %v, got %v", wantErr, err)
+	}
+}
+
+func TestPageLimitsFallsBackToDefaults(t *testing.T) {
+	bt := &Githubbeat{}
+	bt.config.PerPage = 50
+	bt.config.MaxPages = 3
+
+	perPage, maxPages := bt.pageLimits("unconfigured")
+
+	if perPage != 50 || maxPages != 3 {
+		t.Errorf("expected defaults (50, 3), got (%d, %d)", perPage, maxPages)
+	}
+}
+
+func TestPageLimitsAppliesPerCollectorOverride(t *testing.T) {
+	bt := &Githubbeat{}
+	bt.config.PerPage = 50
+	bt.config.MaxPages = 3
+	bt.config.PageLimits = map[string]config.PageLimit{
+		"forks": {PerPage: 10, MaxPages: 1},
+	}
+
+	perPage, maxPages := bt.pageLimits("forks")
+	if perPage != 10 || maxPages != 1 {
+		t.Errorf("expected override (10, 1), got (%d, %d)", perPage, maxPages)
+	}
+
+	perPage, maxPages = bt.pageLimits("branches")
+	if perPage != 50 || maxPages != 3 {
+		t.Errorf("expected defaults (50, 3) for an unrelated collector, got (%d, %d)", perPage, maxPages)
+	}
+}
@@ -0,0 +1,81 @@
+package beater
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/elastic/beats/libbeat/logp"
+)
+
+// registry persists the last successful analytics sync time per owner/repo
+// so incremental runs only pull issues/pull requests updated since then,
+// while a repo with no prior entry does a bounded backfill instead of
+// pulling full history on its first run.
+type registry struct {
+	path string
+
+	mu      sync.Mutex
+	entries map[string]time.Time
+}
+
+func newRegistry(path string) *registry {
+	r := &registry{path: path, entries: make(map[string]time.Time)}
+	r.load()
+
+	return r
+}
+
+func (r *registry) load() {
+	if r.path == "" {
+		return
+	}
+
+	data, err := ioutil.ReadFile(r.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logp.Err("Failed to read analytics registry %s, got: %v", r.path, err)
+		}
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := json.Unmarshal(data, &r.entries); err != nil {
+		logp.Err("Failed to parse analytics registry %s, got: %v", r.path, err)
+	}
+}
+
+// since returns the last successful sync time for key, or now-backfill when
+// key has never been synced.
+func (r *registry) since(key string, backfill time.Duration) time.Time {
+	r.mu.Lock()
+	t, ok := r.entries[key]
+	r.mu.Unlock()
+
+	if ok {
+		return t
+	}
+
+	return time.Now().Add(-backfill)
+}
+
+// markSynced records key as successfully synced as of t and persists the
+// registry to disk.
+func (r *registry) markSynced(key string, t time.Time) {
+	r.mu.Lock()
+	r.entries[key] = t
+	data, err := json.Marshal(r.entries)
+	r.mu.Unlock()
+
+	if r.path == "" || err != nil {
+		return
+	}
+
+	if err := ioutil.WriteFile(r.path, data, 0644); err != nil {
+		logp.Err("Failed to write analytics registry %s, got: %v", r.path, err)
+	}
+}
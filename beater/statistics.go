@@ -0,0 +1,165 @@
+package beater
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+
+	"github.com/google/go-github/github"
+)
+
+// errStatsNotReady is returned when GitHub is still computing a statistics
+// endpoint after the configured retry budget has been exhausted.
+var errStatsNotReady = errors.New("not_ready")
+
+// collectStatistics gathers the weekly contributor, commit-activity, code
+// frequency and punch-card statistics for a repository. GitHub computes
+// these asynchronously, so each sub-collector is fetched independently and
+// a still-computing endpoint only drops its own sub-field rather than
+// failing the whole event.
+func (bt *Githubbeat) collectStatistics(owner, repository string, ctx context.Context) common.MapStr {
+	return common.MapStr{
+		"contributors":   bt.collectContributorStats(owner, repository, ctx),
+		"weekly_commits": bt.collectCommitActivity(owner, repository, ctx),
+		"code_frequency": bt.collectCodeFrequency(owner, repository, ctx),
+		"punchcard":      bt.collectPunchCard(owner, repository, ctx),
+	}
+}
+
+func (bt *Githubbeat) collectContributorStats(owner, repository string, ctx context.Context) common.MapStr {
+	var stats []*github.ContributorStats
+
+	err := bt.statsRetry(func() (*github.Response, error) {
+		s, resp, err := bt.ghClient.Repositories.ListContributorsStats(ctx, owner, repository)
+		stats = s
+		return resp, err
+	})
+
+	if err != nil {
+		return appendError(common.MapStr{}, err)
+	}
+
+	out := []common.MapStr{}
+	for _, stat := range stats {
+		weeks := []common.MapStr{}
+		for _, week := range stat.Weeks {
+			weeks = append(weeks, common.MapStr{
+				"week":      common.Time(week.GetWeek().Time),
+				"additions": week.GetAdditions(),
+				"deletions": week.GetDeletions(),
+				"commits":   week.GetCommits(),
+			})
+		}
+
+		out = append(out, common.MapStr{
+			"author": stat.GetAuthor().GetLogin(),
+			"total":  stat.GetTotal(),
+			"weeks":  weeks,
+		})
+	}
+
+	return common.MapStr{"count": len(out), "list": out}
+}
+
+func (bt *Githubbeat) collectCommitActivity(owner, repository string, ctx context.Context) common.MapStr {
+	var activity []*github.WeeklyCommitActivity
+
+	err := bt.statsRetry(func() (*github.Response, error) {
+		a, resp, err := bt.ghClient.Repositories.ListCommitActivity(ctx, owner, repository)
+		activity = a
+		return resp, err
+	})
+
+	if err != nil {
+		return appendError(common.MapStr{}, err)
+	}
+
+	out := []common.MapStr{}
+	for _, week := range activity {
+		out = append(out, common.MapStr{
+			"week":    common.Time(week.GetWeek().Time),
+			"commits": week.GetTotal(),
+			"days":    week.Days,
+		})
+	}
+
+	return common.MapStr{"count": len(out), "list": out}
+}
+
+func (bt *Githubbeat) collectCodeFrequency(owner, repository string, ctx context.Context) common.MapStr {
+	var frequency []*github.WeeklyStats
+
+	err := bt.statsRetry(func() (*github.Response, error) {
+		f, resp, err := bt.ghClient.Repositories.ListCodeFrequency(ctx, owner, repository)
+		frequency = f
+		return resp, err
+	})
+
+	if err != nil {
+		return appendError(common.MapStr{}, err)
+	}
+
+	out := []common.MapStr{}
+	for _, week := range frequency {
+		out = append(out, common.MapStr{
+			"week":      common.Time(week.GetWeek().Time),
+			"additions": week.GetAdditions(),
+			"deletions": week.GetDeletions(),
+		})
+	}
+
+	return common.MapStr{"count": len(out), "list": out}
+}
+
+func (bt *Githubbeat) collectPunchCard(owner, repository string, ctx context.Context) common.MapStr {
+	var punchcard []*github.PunchCard
+
+	err := bt.statsRetry(func() (*github.Response, error) {
+		p, resp, err := bt.ghClient.Repositories.ListPunchCard(ctx, owner, repository)
+		punchcard = p
+		return resp, err
+	})
+
+	if err != nil {
+		return appendError(common.MapStr{}, err)
+	}
+
+	out := []common.MapStr{}
+	for _, slot := range punchcard {
+		out = append(out, common.MapStr{
+			"day":     slot.GetDay(),
+			"hour":    slot.GetHour(),
+			"commits": slot.GetCommits(),
+		})
+	}
+
+	return common.MapStr{"count": len(out), "list": out}
+}
+
+// statsRetry calls fetch until it succeeds, fails with something other than
+// "still computing", or the configured retry budget is exhausted. GitHub
+// answers a stats endpoint with HTTP 202 and an empty body while it
+// generates the data for a repository it has not cached yet.
+func (bt *Githubbeat) statsRetry(fetch func() (*github.Response, error)) error {
+	backoff := bt.config.StatsRetryBackoff
+
+	for attempt := 0; ; attempt++ {
+		resp, err := fetch()
+
+		if resp == nil || resp.StatusCode != http.StatusAccepted {
+			return err
+		}
+
+		if attempt >= bt.config.StatsMaxRetries {
+			return errStatsNotReady
+		}
+
+		logp.Info("Statistics not ready yet, retrying in %s (attempt %d/%d).", backoff, attempt+1, bt.config.StatsMaxRetries)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
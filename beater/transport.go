@@ -0,0 +1,100 @@
+package beater
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+// cachedResponse holds a previously seen response body together with the
+// validators GitHub expects back on the next request for the same URL.
+type cachedResponse struct {
+	etag         string
+	lastModified string
+	statusCode   int
+	header       http.Header
+	body         []byte
+}
+
+// cachingTransport wraps an http.RoundTripper and turns unchanged GitHub
+// responses into conditional GETs: it replays the ETag/Last-Modified
+// validators recorded on the previous request for a URL, so a 304 (which
+// GitHub counts against the rate limit at zero cost) can be served from the
+// local cache instead of re-fetching the body.
+type cachingTransport struct {
+	next http.RoundTripper
+	bt   *Githubbeat
+
+	mu    sync.Mutex
+	cache map[string]cachedResponse
+}
+
+func newCachingTransport(next http.RoundTripper, bt *Githubbeat) *cachingTransport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &cachingTransport{
+		next:  next,
+		bt:    bt,
+		cache: make(map[string]cachedResponse),
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *cachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := req.URL.String()
+
+	t.mu.Lock()
+	cached, hasCached := t.cache[key]
+	t.mu.Unlock()
+
+	if hasCached {
+		if cached.etag != "" {
+			req.Header.Set("If-None-Match", cached.etag)
+		}
+		if cached.lastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.lastModified)
+		}
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	if t.bt != nil {
+		t.bt.checkRateLimit(resp)
+	}
+
+	if resp.StatusCode == http.StatusNotModified && hasCached {
+		resp.Body.Close()
+		resp.StatusCode = cached.statusCode
+		resp.Header = cached.header
+		resp.Body = ioutil.NopCloser(bytes.NewReader(cached.body))
+
+		return resp, nil
+	}
+
+	if resp.StatusCode == http.StatusOK {
+		body, readErr := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if readErr == nil {
+			t.mu.Lock()
+			t.cache[key] = cachedResponse{
+				etag:         resp.Header.Get("ETag"),
+				lastModified: resp.Header.Get("Last-Modified"),
+				statusCode:   resp.StatusCode,
+				header:       resp.Header,
+				body:         body,
+			}
+			t.mu.Unlock()
+		}
+
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	return resp, nil
+}
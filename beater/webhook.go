@@ -0,0 +1,155 @@
+package beater
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+
+	"github.com/google/go-github/github"
+)
+
+// startWebhookServer starts the HTTP listener used by "webhook"/"both" run
+// modes. It verifies GitHub's X-Hub-Signature-256 HMAC, parses the payload
+// and translates it into a githubbeat.webhook event, eliminating polling
+// latency for busy repos.
+func (bt *Githubbeat) startWebhookServer() error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", bt.handleWebhook)
+
+	bt.webhookServer = &http.Server{Addr: bt.config.Webhook.Address, Handler: mux}
+
+	go func() {
+		var err error
+
+		if bt.config.Webhook.TLSCertFile != "" && bt.config.Webhook.TLSKeyFile != "" {
+			err = bt.webhookServer.ListenAndServeTLS(bt.config.Webhook.TLSCertFile, bt.config.Webhook.TLSKeyFile)
+		} else {
+			err = bt.webhookServer.ListenAndServe()
+		}
+
+		if err != nil && err != http.ErrServerClosed {
+			logp.Err("Webhook server stopped unexpectedly, got: %v", err)
+		}
+	}()
+
+	logp.Info("Webhook receiver listening on %s.", bt.config.Webhook.Address)
+
+	return nil
+}
+
+// stopWebhookServer gracefully drains in-flight handlers, if the webhook
+// server was started.
+func (bt *Githubbeat) stopWebhookServer() {
+	if bt.webhookServer == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), bt.config.JobTimeout)
+	defer cancel()
+
+	if err := bt.webhookServer.Shutdown(ctx); err != nil {
+		logp.Err("Failed to shut down webhook server cleanly, got: %v", err)
+	}
+}
+
+func (bt *Githubbeat) handleWebhook(w http.ResponseWriter, r *http.Request) {
+	payload, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	if !bt.verifySignature(r, payload) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	eventType := github.WebHookType(r)
+
+	event, err := github.ParseWebHook(eventType, payload)
+	if err != nil {
+		logp.Err("Failed to parse webhook payload, got: %v", err)
+		http.Error(w, "failed to parse payload", http.StatusBadRequest)
+		return
+	}
+
+	bt.client.PublishEvent(common.MapStr{
+		"@timestamp": common.Time(time.Now()),
+		"type":       "githubbeat.webhook",
+		"event":      eventType,
+		"payload":    translateWebhookEvent(event),
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (bt *Githubbeat) verifySignature(r *http.Request, payload []byte) bool {
+	if bt.config.Webhook.Secret == "" {
+		return true
+	}
+
+	signature := strings.TrimPrefix(r.Header.Get("X-Hub-Signature-256"), "sha256=")
+
+	expected := hmac.New(sha256.New, []byte(bt.config.Webhook.Secret))
+	expected.Write(payload)
+
+	return hmac.Equal([]byte(signature), []byte(hex.EncodeToString(expected.Sum(nil))))
+}
+
+// translateWebhookEvent maps the handful of event types githubbeat cares
+// about onto the same shape as the poll-mode collectors, so Kibana
+// dashboards can treat both sources uniformly.
+func translateWebhookEvent(event interface{}) common.MapStr {
+	switch e := event.(type) {
+	case *github.PushEvent:
+		return common.MapStr{
+			"repo":    e.GetRepo().GetFullName(),
+			"ref":     e.GetRef(),
+			"commits": len(e.Commits),
+		}
+	case *github.PullRequestEvent:
+		return common.MapStr{
+			"repo":   e.GetRepo().GetFullName(),
+			"action": e.GetAction(),
+			"number": e.GetNumber(),
+		}
+	case *github.IssuesEvent:
+		return common.MapStr{
+			"repo":   e.GetRepo().GetFullName(),
+			"action": e.GetAction(),
+			"number": e.GetIssue().GetNumber(),
+		}
+	case *github.ReleaseEvent:
+		return common.MapStr{
+			"repo":   e.GetRepo().GetFullName(),
+			"action": e.GetAction(),
+			"tag":    e.GetRelease().GetTagName(),
+		}
+	case *github.StarEvent:
+		return common.MapStr{
+			"repo":   e.GetRepo().GetFullName(),
+			"action": e.GetAction(),
+		}
+	case *github.ForkEvent:
+		return common.MapStr{
+			"repo": e.GetRepo().GetFullName(),
+			"fork": e.GetForkee().GetFullName(),
+		}
+	case *github.WorkflowRunEvent:
+		return common.MapStr{
+			"repo":       e.GetRepo().GetFullName(),
+			"action":     e.GetAction(),
+			"conclusion": e.GetWorkflowRun().GetConclusion(),
+		}
+	default:
+		return common.MapStr{}
+	}
+}
@@ -0,0 +1,169 @@
+package beater
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/google/go-github/github"
+)
+
+func sign(secret, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifySignature(t *testing.T) {
+	payload := `{"zen": "hello"}`
+
+	tests := []struct {
+		name      string
+		secret    string
+		header    string
+		wantValid bool
+	}{
+		{
+			name:      "valid signature",
+			secret:    "s3cr3t",
+			header:    sign("s3cr3t", payload),
+			wantValid: true,
+		},
+		{
+			name:      "wrong secret",
+			secret:    "s3cr3t",
+			header:    sign("not-the-secret", payload),
+			wantValid: false,
+		},
+		{
+			name:      "missing header",
+			secret:    "s3cr3t",
+			header:    "",
+			wantValid: false,
+		},
+		{
+			name:      "no secret configured accepts anything",
+			secret:    "",
+			header:    "",
+			wantValid: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bt := &Githubbeat{}
+			bt.config.Webhook.Secret = tt.secret
+
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(payload))
+			if tt.header != "" {
+				req.Header.Set("X-Hub-Signature-256", tt.header)
+			}
+
+			if got := bt.verifySignature(req, []byte(payload)); got != tt.wantValid {
+				t.Errorf("verifySignature() = %v, want %v", got, tt.wantValid)
+			}
+		})
+	}
+}
+
+func TestTranslateWebhookEvent(t *testing.T) {
+	repo := &github.Repository{FullName: github.String("owner/repo")}
+
+	tests := []struct {
+		name  string
+		event interface{}
+		want  common.MapStr
+	}{
+		{
+			name: "push",
+			event: &github.PushEvent{
+				Repo:    &github.PushEventRepository{FullName: github.String("owner/repo")},
+				Ref:     github.String("refs/heads/main"),
+				Commits: []github.PushEventCommit{{}, {}},
+			},
+			want: common.MapStr{"repo": "owner/repo", "ref": "refs/heads/main", "commits": 2},
+		},
+		{
+			name: "pull_request",
+			event: &github.PullRequestEvent{
+				Repo:        repo,
+				Action:      github.String("opened"),
+				Number:      github.Int(42),
+				PullRequest: &github.PullRequest{},
+			},
+			want: common.MapStr{"repo": "owner/repo", "action": "opened", "number": 42},
+		},
+		{
+			name: "issues",
+			event: &github.IssuesEvent{
+				Repo:   repo,
+				Action: github.String("closed"),
+				Issue:  &github.Issue{Number: github.Int(7)},
+			},
+			want: common.MapStr{"repo": "owner/repo", "action": "closed", "number": 7},
+		},
+		{
+			name: "release",
+			event: &github.ReleaseEvent{
+				Repo:    repo,
+				Action:  github.String("published"),
+				Release: &github.RepositoryRelease{TagName: github.String("v1.0.0")},
+			},
+			want: common.MapStr{"repo": "owner/repo", "action": "published", "tag": "v1.0.0"},
+		},
+		{
+			name: "star",
+			event: &github.StarEvent{
+				Repo:   repo,
+				Action: github.String("created"),
+			},
+			want: common.MapStr{"repo": "owner/repo", "action": "created"},
+		},
+		{
+			name: "fork",
+			event: &github.ForkEvent{
+				Repo:   repo,
+				Forkee: &github.Repository{FullName: github.String("owner/repo-fork")},
+			},
+			want: common.MapStr{"repo": "owner/repo", "fork": "owner/repo-fork"},
+		},
+		{
+			name: "workflow_run",
+			event: &github.WorkflowRunEvent{
+				Repo:   repo,
+				Action: github.String("completed"),
+				WorkflowRun: &github.WorkflowRun{
+					Conclusion: github.String("success"),
+				},
+			},
+			want: common.MapStr{"repo": "owner/repo", "action": "completed", "conclusion": "success"},
+		},
+		{
+			name:  "unknown event type",
+			event: &github.WatchEvent{},
+			want:  common.MapStr{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := translateWebhookEvent(tt.event)
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("translateWebhookEvent() = %#v, want %#v", got, tt.want)
+			}
+
+			for key, want := range tt.want {
+				if got[key] != want {
+					t.Errorf("translateWebhookEvent()[%q] = %v, want %v", key, got[key], want)
+				}
+			}
+		})
+	}
+}
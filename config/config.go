@@ -0,0 +1,132 @@
+package config
+
+import "time"
+
+// Run modes accepted by the Mode config key.
+const (
+	ModePoll    = "poll"
+	ModeWebhook = "webhook"
+	ModeBoth    = "both"
+)
+
+// Config holds the githubbeat configuration as read from githubbeat.yml
+type Config struct {
+	Period      time.Duration `config:"period"`
+	JobTimeout  time.Duration `config:"job_timeout"`
+	AccessToken string        `config:"access_token"`
+	Repos       []string      `config:"repos"`
+	Orgs        []string      `config:"orgs"`
+
+	// PerPage is the default page size used when paginating GitHub list
+	// endpoints. GitHub caps this at 100.
+	PerPage int `config:"per_page"`
+	// MaxPages caps the number of pages fetched per collector call, 0 means
+	// no limit (follow every page GitHub hands back).
+	MaxPages int `config:"max_pages"`
+	// PageLimits allows overriding PerPage/MaxPages for a specific
+	// collector, keyed by collector name (e.g. "forks", "contributors",
+	// "branches", "downloads", "org_repos").
+	PageLimits map[string]PageLimit `config:"page_limits"`
+
+	// RateLimitThreshold is the X-RateLimit-Remaining value below which the
+	// beat pauses polling until the rate limit window resets.
+	RateLimitThreshold int `config:"rate_limit_threshold"`
+
+	// StatsMaxRetries caps how many times the statistics collector retries
+	// a GitHub stats endpoint that answers 202 while it computes the data.
+	StatsMaxRetries int `config:"stats_max_retries"`
+	// StatsRetryBackoff is the initial delay between stats retries, doubled
+	// after each attempt.
+	StatsRetryBackoff time.Duration `config:"stats_retry_backoff"`
+
+	// EnterpriseBaseURL and EnterpriseUploadURL point githubbeat at a
+	// GitHub Enterprise Server instance instead of api.github.com. Both
+	// must be set together.
+	EnterpriseBaseURL   string `config:"enterprise_base_url"`
+	EnterpriseUploadURL string `config:"enterprise_upload_url"`
+	// InsecureSkipVerify disables TLS certificate verification, for GHES
+	// instances running with a self-signed certificate.
+	InsecureSkipVerify bool `config:"insecure_skip_verify"`
+	// CACert is a path to a PEM-encoded CA bundle to trust in addition to
+	// the system roots, for GHES instances with a private CA.
+	CACert string `config:"ca_cert"`
+
+	// Analytics configures the issue/pull-request analytics collectors.
+	Analytics AnalyticsConfig `config:"analytics"`
+
+	// Queries is a list of GitHub search qualifiers (e.g. "topic:beats
+	// language:go stars:>50") resolved into a repo set at every
+	// discovery_refresh interval, in addition to the static Repos/Orgs
+	// lists.
+	Queries []string `config:"queries"`
+	// DiscoveryRefresh is how often the Queries search results are
+	// re-resolved. Between refreshes the last resolved set is reused so
+	// search quota isn't burned every tick.
+	DiscoveryRefresh time.Duration `config:"discovery_refresh"`
+
+	// Mode selects how githubbeat collects events: "poll" (default),
+	// "webhook", or "both".
+	Mode string `config:"mode"`
+	// Webhook configures the HTTP listener used in "webhook"/"both" mode.
+	Webhook WebhookConfig `config:"webhook"`
+
+	// AppID, InstallationID and PrivateKeyPath configure GitHub App
+	// installation authentication, an alternative to AccessToken. All
+	// three must be set together, and they are mutually exclusive with
+	// AccessToken.
+	AppID          int64  `config:"app_id"`
+	InstallationID int64  `config:"installation_id"`
+	PrivateKeyPath string `config:"private_key_path"`
+}
+
+// WebhookConfig configures the webhook-receiver run mode.
+type WebhookConfig struct {
+	// Address is the address the webhook HTTP listener binds to, e.g.
+	// ":9090".
+	Address string `config:"address"`
+	// Secret is the webhook secret configured on the GitHub side, used to
+	// verify the X-Hub-Signature-256 header.
+	Secret string `config:"secret"`
+	// TLSCertFile and TLSKeyFile, when both set, serve the webhook
+	// listener over HTTPS.
+	TLSCertFile string `config:"tls_cert_file"`
+	TLSKeyFile  string `config:"tls_key_file"`
+}
+
+// AnalyticsConfig configures the issue/pull-request analytics collectors.
+type AnalyticsConfig struct {
+	// Window is how far back "opened/closed in the last N days" counts
+	// look.
+	Window time.Duration `config:"window"`
+	// RegistryPath is where the per-repo last-successful-sync timestamps
+	// are persisted, so incremental runs only pull issues/PRs updated
+	// since then. Empty disables persistence to disk: sync times are then
+	// kept in memory only, so each restart of the beat does a backfill.
+	RegistryPath string `config:"registry_path"`
+	// Backfill bounds how far back a repo with no registry entry looks on
+	// its first, cold-start run.
+	Backfill time.Duration `config:"backfill"`
+}
+
+// PageLimit overrides the default pagination settings for a single collector.
+type PageLimit struct {
+	PerPage  int `config:"per_page"`
+	MaxPages int `config:"max_pages"`
+}
+
+// DefaultConfig is the default configuration for githubbeat
+var DefaultConfig = Config{
+	Period:             1 * time.Minute,
+	JobTimeout:         30 * time.Second,
+	PerPage:            100,
+	MaxPages:           0,
+	RateLimitThreshold: 100,
+	StatsMaxRetries:    3,
+	StatsRetryBackoff:  2 * time.Second,
+	Analytics: AnalyticsConfig{
+		Window:   30 * 24 * time.Hour,
+		Backfill: 90 * 24 * time.Hour,
+	},
+	DiscoveryRefresh: 1 * time.Hour,
+	Mode:             ModePoll,
+}